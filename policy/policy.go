@@ -4,6 +4,8 @@ package policy
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"strconv"
 	"time"
 )
@@ -25,8 +27,8 @@ const (
 type ConditionKey string
 
 // conditions supported by default. See AWS docs for rules when using each.
-// TODO Document Support x-amz-meta-*
-// TODO Document support x-amz-*
+// Arbitrary x-amz-meta-* and x-amz-* conditions are supported through
+// SetMetadata and SetAMZHeader rather than a fixed key.
 const (
 	ConditionKeyACL                   ConditionKey = "acl"
 	ConditionKeyBucket                             = "bucket"
@@ -56,6 +58,32 @@ type Policy struct {
 
 	// conditions should only be set with SetCondition or SetRangeCondition.
 	conditions []condition
+
+	// clock is used by WithExpiresIn. A nil clock falls back to time.Now.
+	clock Clock
+}
+
+// Clock returns the current time. Tests and replay scenarios can supply a
+// fixed Clock through SetClock so policies and signatures come out
+// byte-identical across runs.
+type Clock func() time.Time
+
+// SetClock overrides the time source WithExpiresIn uses to compute
+// Expiration. It does not change an Expiration that is already set; only
+// later WithExpiresIn calls use c.
+func (p *Policy) SetClock(c Clock) {
+	p.clock = c
+}
+
+// WithExpiresIn sets Expiration to d from now and returns p, for chaining
+// into a Policy literal or builder call.
+func (p *Policy) WithExpiresIn(d time.Duration) *Policy {
+	now := time.Now
+	if p.clock != nil {
+		now = p.clock
+	}
+	p.Expiration = now().Add(d)
+	return p
 }
 
 // SetCondition adds a POST policy condition to the policy.
@@ -82,6 +110,184 @@ func (p *Policy) SetRangeCondition(k ConditionKey, l, u uint64) {
 	p.conditions = append(p.conditions, c)
 }
 
+// CannedACL is one of the canned ACLs S3 accepts for the acl condition.
+type CannedACL string
+
+const (
+	ACLPrivate                CannedACL = "private"
+	ACLPublicRead             CannedACL = "public-read"
+	ACLPublicReadWrite        CannedACL = "public-read-write"
+	ACLAuthenticatedRead      CannedACL = "authenticated-read"
+	ACLBucketOwnerRead        CannedACL = "bucket-owner-read"
+	ACLBucketOwnerFullControl CannedACL = "bucket-owner-full-control"
+)
+
+// SuccessActionStatus is one of the HTTP status codes S3 will respond with
+// on a successful upload when no success_action_redirect is set.
+type SuccessActionStatus int
+
+const (
+	SuccessActionStatusOK        SuccessActionStatus = 200
+	SuccessActionStatusCreated   SuccessActionStatus = 201
+	SuccessActionStatusNoContent SuccessActionStatus = 204
+)
+
+// SetBucket adds an exact-match condition for the bucket the upload must
+// target.
+func (p *Policy) SetBucket(name string) {
+	p.SetCondition(ConditionKeyBucket, name, ConditionMatchExact)
+}
+
+// SetACL adds an exact-match condition requiring the given canned ACL.
+func (p *Policy) SetACL(acl CannedACL) {
+	p.SetCondition(ConditionKeyACL, string(acl), ConditionMatchExact)
+}
+
+// SetKey adds an exact-match condition requiring the uploaded object's key.
+func (p *Policy) SetKey(name string) {
+	p.SetCondition(ConditionKeyKey, name, ConditionMatchExact)
+}
+
+// SetKeyStartsWith adds a starts-with condition requiring the uploaded
+// object's key to begin with prefix.
+func (p *Policy) SetKeyStartsWith(prefix string) {
+	p.SetCondition(ConditionKeyKey, prefix, ConditionMatchStartsWith)
+}
+
+// SetContentType adds a condition on the Content-Type form field. If
+// startsWith is true, mime is treated as a prefix rather than an exact
+// value.
+func (p *Policy) SetContentType(mime string, startsWith bool) {
+	p.SetCondition(ConditionKeyContentType, mime, matchFor(startsWith))
+}
+
+// SetContentLengthRange adds a condition requiring the uploaded file's size
+// to fall between min and max, inclusive.
+func (p *Policy) SetContentLengthRange(min, max uint64) {
+	p.SetRangeCondition(ConditionKeyContentLengthRange, min, max)
+}
+
+// SetSuccessActionStatus adds an exact-match condition requiring the given
+// HTTP status on a successful upload.
+func (p *Policy) SetSuccessActionStatus(status SuccessActionStatus) {
+	p.SetCondition(ConditionKeySuccessActionStatus, strconv.Itoa(int(status)), ConditionMatchExact)
+}
+
+// SetSuccessActionRedirect adds an exact-match condition requiring the
+// browser be redirected to url on a successful upload.
+func (p *Policy) SetSuccessActionRedirect(url string) {
+	p.SetCondition(ConditionKeySuccessActionRedirect, url, ConditionMatchExact)
+}
+
+// SetCacheControl adds an exact-match condition on the Cache-Control form
+// field.
+func (p *Policy) SetCacheControl(value string) {
+	p.SetCondition(ConditionKeyCacheControl, value, ConditionMatchExact)
+}
+
+// SetContentDisposition adds an exact-match condition on the
+// Content-Disposition form field.
+func (p *Policy) SetContentDisposition(value string) {
+	p.SetCondition(ConditionKeyContentDisposition, value, ConditionMatchExact)
+}
+
+// SetContentEncoding adds an exact-match condition on the Content-Encoding
+// form field.
+func (p *Policy) SetContentEncoding(value string) {
+	p.SetCondition(ConditionKeyContentEncoding, value, ConditionMatchExact)
+}
+
+// SetExpires adds an exact-match condition on the Expires form field,
+// formatted the way HTTP headers are.
+func (p *Policy) SetExpires(t time.Time) {
+	p.SetCondition(ConditionKeyExpires, t.UTC().Format(http.TimeFormat), ConditionMatchExact)
+}
+
+// SetMetadata adds a condition on an x-amz-meta-<key> form field. If
+// startsWith is true, value is treated as a prefix rather than an exact
+// value.
+func (p *Policy) SetMetadata(key, value string, startsWith bool) {
+	p.SetCondition(ConditionKey("x-amz-meta-"+key), value, matchFor(startsWith))
+}
+
+// SetAMZHeader adds a condition on an arbitrary x-amz-<name> form field. If
+// startsWith is true, value is treated as a prefix rather than an exact
+// value.
+func (p *Policy) SetAMZHeader(name, value string, startsWith bool) {
+	p.SetCondition(ConditionKey("x-amz-"+name), value, matchFor(startsWith))
+}
+
+// matchFor returns ConditionMatchStartsWith if startsWith, else
+// ConditionMatchExact.
+func matchFor(startsWith bool) ConditionMatch {
+	if startsWith {
+		return ConditionMatchStartsWith
+	}
+	return ConditionMatchExact
+}
+
+// Validate checks p against the AWS rules for POST policies: a bucket
+// condition is required, content-length-range may only be set with
+// SetContentLengthRange (not as an exact or starts-with match),
+// success_action_redirect and success_action_status are mutually
+// exclusive, x-amz-algorithm/x-amz-credential/x-amz-date must either all be
+// absent or all be set as exact matches, and no key may have more than one
+// exact-match condition.
+func (p Policy) Validate() error {
+	present := make(map[ConditionKey]bool, len(p.conditions))
+	exactCount := make(map[ConditionKey]int, len(p.conditions))
+	exactValue := make(map[ConditionKey]string, len(p.conditions))
+
+	for _, c := range p.conditions {
+		present[c.key] = true
+
+		if c.key == ConditionKeyContentLengthRange && c.match != ConditionMatchRange {
+			return fmt.Errorf("policy: %s must be set with SetContentLengthRange, not an exact or starts-with match", c.key)
+		}
+
+		if c.match == ConditionMatchExact {
+			exactCount[c.key]++
+			if exactCount[c.key] > 1 {
+				return fmt.Errorf("policy: duplicate exact-match condition for %s", c.key)
+			}
+			exactValue[c.key] = c.value
+		}
+	}
+
+	if !present[ConditionKeyBucket] {
+		return fmt.Errorf("policy: %s condition is required", ConditionKeyBucket)
+	}
+
+	if present[ConditionKeySuccessActionRedirect] && present[ConditionKeySuccessActionStatus] {
+		return fmt.Errorf("policy: %s and %s are mutually exclusive", ConditionKeySuccessActionRedirect, ConditionKeySuccessActionStatus)
+	}
+
+	if v, ok := exactValue[ConditionKeySuccessActionStatus]; ok {
+		switch v {
+		case "200", "201", "204":
+		default:
+			return fmt.Errorf("policy: %s must be 200, 201, or 204, got %q", ConditionKeySuccessActionStatus, v)
+		}
+	}
+
+	amzKeys := [...]ConditionKey{ConditionKeyAMZAlgorithm, ConditionKeyAMZCredential, ConditionKeyAMZDate}
+	amzPresent, amzExact := 0, 0
+	for _, k := range amzKeys {
+		if present[k] {
+			amzPresent++
+		}
+		if exactCount[k] == 1 {
+			amzExact++
+		}
+	}
+	if amzPresent != 0 && amzExact != len(amzKeys) {
+		return fmt.Errorf("policy: %s, %s, and %s must either all be absent or all be set as exact matches",
+			ConditionKeyAMZAlgorithm, ConditionKeyAMZCredential, ConditionKeyAMZDate)
+	}
+
+	return nil
+}
+
 // policyJSON is needed to convert the Expiration time into a string inside of
 // MarshalJSON.
 type policyJSON struct {
@@ -122,7 +328,16 @@ func (c condition) MarshalJSON() ([]byte, error) {
 		return json.Marshal(m)
 	}
 
-	// The other match types are arrays with 3 elements.
+	// Specifying Ranges:
+	// ["content-length-range", 1048579, 10485760]
+	// The bounds are JSON numbers, not strings, so this case is marshaled
+	// separately from the other (all-string) array match types below.
+	if c.match == ConditionMatchRange {
+		a := [3]interface{}{string(c.key), c.rangeLower, c.rangeUpper}
+		return json.Marshal(a)
+	}
+
+	// The remaining match types are arrays of 3 strings.
 	var a [3]string
 
 	if c.match == ConditionMatchStartsWith {
@@ -137,12 +352,6 @@ func (c condition) MarshalJSON() ([]byte, error) {
 		a[0] = "starts-with"
 		a[1] = "$" + string(c.key)
 		a[2] = ""
-	} else if c.match == ConditionMatchRange {
-		// Specifying Ranges
-		// ["content-length-range", 1048579, 10485760]
-		a[0] = string(c.key)
-		a[1] = strconv.FormatUint(c.rangeLower, 10)
-		a[2] = strconv.FormatUint(c.rangeUpper, 10)
 	}
 
 	return json.Marshal(a)
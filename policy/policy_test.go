@@ -0,0 +1,48 @@
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicyWithExpiresInUsesClock(t *testing.T) {
+	fixed := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var p Policy
+	p.SetClock(func() time.Time { return fixed })
+	p.WithExpiresIn(time.Hour)
+
+	want := fixed.Add(time.Hour)
+	if !p.Expiration.Equal(want) {
+		t.Fatalf("Expiration = %v, want %v", p.Expiration, want)
+	}
+}
+
+func TestPolicyWithExpiresInDeterministic(t *testing.T) {
+	clock := func() time.Time {
+		return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+
+	var a, b Policy
+	a.SetClock(clock)
+	b.SetClock(clock)
+
+	a.WithExpiresIn(30 * time.Minute)
+	b.WithExpiresIn(30 * time.Minute)
+
+	if !a.Expiration.Equal(b.Expiration) {
+		t.Fatalf("Expiration mismatch across policies sharing a clock: %v != %v", a.Expiration, b.Expiration)
+	}
+}
+
+func TestPolicyWithExpiresInDefaultsToWallClock(t *testing.T) {
+	before := time.Now()
+
+	var p Policy
+	p.WithExpiresIn(time.Minute)
+
+	after := time.Now().Add(time.Minute)
+	if p.Expiration.Before(before.Add(time.Minute)) || p.Expiration.After(after) {
+		t.Fatalf("Expiration = %v, want between %v and %v", p.Expiration, before.Add(time.Minute), after)
+	}
+}
@@ -0,0 +1,205 @@
+package policy
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Credentials holds the AWS access key and secret key used to sign a
+// policy. SessionToken is optional and should be set when using temporary
+// credentials issued by AWS STS.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// SignedPolicy holds everything needed to render the hidden form fields for a
+// browser-based POST upload once a Policy has been signed.
+type SignedPolicy struct {
+	// Policy is the base64-encoded policy document.
+	Policy string
+
+	// Credential is the x-amz-credential value:
+	// <access key>/<date>/<region>/<service>/aws4_request
+	Credential string
+
+	// Date is the x-amz-date value, e.g. 20060102T150405Z.
+	Date string
+
+	// Signature is the hex-encoded SigV4 signature of Policy.
+	Signature string
+
+	// SecurityToken is the STS session token, if creds.SessionToken was set.
+	SecurityToken string
+
+	// Region is the AWS region the policy was signed for, as passed to
+	// Sign. FormAction uses it to build a region-specific endpoint, which
+	// opt-in regions (e.g. ap-east-1, me-south-1) require.
+	Region string
+}
+
+// Sign computes a SigV4 signature for p using creds, and returns a
+// SignedPolicy with the base64 policy, credential scope, date, and
+// signature needed to render a POST upload form.
+//
+// Sign injects exact-match x-amz-algorithm, x-amz-credential, and
+// x-amz-date conditions into p before marshaling so they always agree
+// with the returned signature. If creds.SessionToken is set, an
+// exact-match x-amz-security-token condition is injected too. region and
+// service are typically the bucket's AWS region and "s3". Sign calls
+// p.Validate() after injecting those conditions and returns its error
+// rather than signing a policy that the S3/gateway boundary would reject.
+func Sign(p Policy, creds Credentials, region, service string, now time.Time) (SignedPolicy, error) {
+	// p.conditions may share a backing array with the caller's Policy, which
+	// is reused across concurrent Sign calls (e.g. one template policy
+	// signed per incoming upload request). Clone before appending so those
+	// calls don't race on the same underlying array.
+	p.conditions = append([]condition(nil), p.conditions...)
+
+	dateStamp := now.Format("20060102")
+	amzDate := now.Format("20060102T150405Z")
+	credentialScope := fmt.Sprintf("%s/%s/%s/aws4_request", dateStamp, region, service)
+	credential := fmt.Sprintf("%s/%s", creds.AccessKeyID, credentialScope)
+
+	p.SetCondition(ConditionKeyAMZAlgorithm, AWSV4SignatureAlgorithm, ConditionMatchExact)
+	p.SetCondition(ConditionKeyAMZCredential, credential, ConditionMatchExact)
+	p.SetCondition(ConditionKeyAMZDate, amzDate, ConditionMatchExact)
+	if creds.SessionToken != "" {
+		p.SetCondition(ConditionKeyAMZSecurityToken, creds.SessionToken, ConditionMatchExact)
+	}
+
+	if err := p.Validate(); err != nil {
+		return SignedPolicy{}, err
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return SignedPolicy{}, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(b)
+
+	key := signingKeyV4(creds.SecretAccessKey, dateStamp, region, service)
+	sig := hmacSHA256(key, []byte(encoded))
+
+	return SignedPolicy{
+		Policy:        encoded,
+		Credential:    credential,
+		Date:          amzDate,
+		Signature:     hex.EncodeToString(sig),
+		SecurityToken: creds.SessionToken,
+		Region:        region,
+	}, nil
+}
+
+// signingKeyV4 derives the SigV4 signing key using the standard chain:
+// HMAC(HMAC(HMAC(HMAC("AWS4"+secret, date), region), service), "aws4_request")
+func signingKeyV4(secret, dateStamp, region, service string) []byte {
+	kDate := hmacSHA256([]byte("AWS4"+secret), []byte(dateStamp))
+	kRegion := hmacSHA256(kDate, []byte(region))
+	kService := hmacSHA256(kRegion, []byte(service))
+	return hmacSHA256(kService, []byte("aws4_request"))
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// FormFields returns the hidden form fields a browser upload form needs in
+// addition to the file field itself.
+func (sp SignedPolicy) FormFields() map[string]string {
+	fields := map[string]string{
+		"policy":           sp.Policy,
+		"x-amz-algorithm":  AWSV4SignatureAlgorithm,
+		"x-amz-credential": sp.Credential,
+		"x-amz-date":       sp.Date,
+		"x-amz-signature":  sp.Signature,
+	}
+	if sp.SecurityToken != "" {
+		fields["x-amz-security-token"] = sp.SecurityToken
+	}
+	return fields
+}
+
+// FormAction returns the region-specific URL a POST upload form for bucket
+// should target.
+func (sp SignedPolicy) FormAction(bucket string) string {
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", bucket, sp.Region)
+}
+
+// SignedPolicyV2 holds the hidden form fields for a browser-based POST
+// upload signed with the legacy SigV2 scheme, used by some S3-compatible
+// stores and older AWS buckets. Unlike SignedPolicy, it has no FormAction:
+// SigV2 carries no region, and the upload endpoint for an S3-compatible
+// gateway is gateway-specific, so callers must build that URL themselves.
+type SignedPolicyV2 struct {
+	// AWSAccessKeyID is the access key ID the policy was signed with.
+	AWSAccessKeyID string
+
+	// Policy is the base64-encoded policy document.
+	Policy string
+
+	// Signature is the base64-encoded HMAC-SHA1 signature of Policy.
+	Signature string
+
+	// SecurityToken is the STS session token, if creds.SessionToken was set.
+	SecurityToken string
+}
+
+// SignV2 signs p with the legacy SigV2 scheme: a base64-encoded HMAC-SHA1
+// of the base64 policy document, keyed with creds.SecretAccessKey. SignV2
+// calls p.Validate() first and returns its error rather than signing an
+// invalid policy. If creds.SessionToken is set, an exact-match
+// x-amz-security-token condition is injected before signing, same as Sign.
+func SignV2(p Policy, creds Credentials) (SignedPolicyV2, error) {
+	// See the matching comment in Sign: clone before any future appends so
+	// concurrent signing of one shared template Policy can't race.
+	p.conditions = append([]condition(nil), p.conditions...)
+
+	if creds.SessionToken != "" {
+		p.SetCondition(ConditionKeyAMZSecurityToken, creds.SessionToken, ConditionMatchExact)
+	}
+
+	if err := p.Validate(); err != nil {
+		return SignedPolicyV2{}, err
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return SignedPolicyV2{}, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(b)
+
+	h := hmac.New(sha1.New, []byte(creds.SecretAccessKey))
+	h.Write([]byte(encoded))
+	sig := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	return SignedPolicyV2{
+		AWSAccessKeyID: creds.AccessKeyID,
+		Policy:         encoded,
+		Signature:      sig,
+		SecurityToken:  creds.SessionToken,
+	}, nil
+}
+
+// FormFields returns the hidden form fields a browser upload form needs in
+// addition to the file field itself.
+func (sp SignedPolicyV2) FormFields() map[string]string {
+	fields := map[string]string{
+		"AWSAccessKeyId": sp.AWSAccessKeyID,
+		"policy":         sp.Policy,
+		"signature":      sp.Signature,
+	}
+	if sp.SecurityToken != "" {
+		fields["x-amz-security-token"] = sp.SecurityToken
+	}
+	return fields
+}
@@ -0,0 +1,86 @@
+package policy
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestSignProducesExpectedFields(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.WithExpiresIn(time.Hour)
+
+	creds := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	sp, err := Sign(p, creds, "us-east-1", "s3", now)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	wantCredential := "AKID/20260102/us-east-1/s3/aws4_request"
+	if sp.Credential != wantCredential {
+		t.Errorf("Credential = %q, want %q", sp.Credential, wantCredential)
+	}
+
+	if want := "20260102T030405Z"; sp.Date != want {
+		t.Errorf("Date = %q, want %q", sp.Date, want)
+	}
+
+	if !regexp.MustCompile(`^[0-9a-f]{64}$`).MatchString(sp.Signature) {
+		t.Errorf("Signature = %q, want 64 lower-case hex characters", sp.Signature)
+	}
+
+	if sp.Region != "us-east-1" {
+		t.Errorf("Region = %q, want %q", sp.Region, "us-east-1")
+	}
+}
+
+func TestFormFieldsOmitsSecurityTokenWhenUnset(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.WithExpiresIn(time.Hour)
+
+	creds := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}
+	sp, err := Sign(p, creds, "us-east-1", "s3", time.Now())
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	fields := sp.FormFields()
+	for _, key := range []string{"policy", "x-amz-algorithm", "x-amz-credential", "x-amz-date", "x-amz-signature"} {
+		if fields[key] == "" {
+			t.Errorf("FormFields()[%q] is empty, want a value", key)
+		}
+	}
+	if _, ok := fields["x-amz-security-token"]; ok {
+		t.Error("FormFields() included x-amz-security-token with no SessionToken set")
+	}
+}
+
+func TestFormActionUsesRegion(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.WithExpiresIn(time.Hour)
+
+	creds := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}
+	sp, err := Sign(p, creds, "ap-east-1", "s3", time.Now())
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	want := "https://my-bucket.s3.ap-east-1.amazonaws.com/"
+	if got := sp.FormAction("my-bucket"); got != want {
+		t.Errorf("FormAction() = %q, want %q", got, want)
+	}
+}
+
+func TestSignRejectsInvalidPolicy(t *testing.T) {
+	var p Policy // no bucket condition
+
+	creds := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}
+	if _, err := Sign(p, creds, "us-east-1", "s3", time.Now()); err == nil {
+		t.Fatal("Sign() error = nil, want error for a policy missing a bucket condition")
+	}
+}
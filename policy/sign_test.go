@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func signedTestPolicy(t *testing.T) (Policy, Credentials, SignedPolicy) {
+	t.Helper()
+
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.SetKey("uploads/file.txt")
+	p.SetContentLengthRange(1, 1000)
+	p.WithExpiresIn(time.Hour)
+
+	creds := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}
+
+	sp, err := Sign(p, creds, "us-east-1", "s3", time.Now())
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	return p, creds, sp
+}
+
+func testLookup(creds Credentials) SecretLookupFunc {
+	return func(accessKey string) (string, bool) {
+		if accessKey != creds.AccessKeyID {
+			return "", false
+		}
+		return creds.SecretAccessKey, true
+	}
+}
+
+func formValues(sp SignedPolicy) url.Values {
+	values := url.Values{}
+	for k, v := range sp.FormFields() {
+		values.Set(k, v)
+	}
+	values.Set("bucket", "my-bucket")
+	values.Set("key", "uploads/file.txt")
+	return values
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	_, creds, sp := signedTestPolicy(t)
+	v := NewVerifier(testLookup(creds))
+
+	if err := v.Verify(formValues(sp), 500, time.Now()); err != nil {
+		t.Fatalf("Verify() error = %v, want nil", err)
+	}
+}
+
+func TestVerifyRejectsTamperedField(t *testing.T) {
+	_, creds, sp := signedTestPolicy(t)
+	v := NewVerifier(testLookup(creds))
+
+	values := formValues(sp)
+	values.Set("key", "uploads/other-file.txt")
+
+	if err := v.Verify(values, 500, time.Now()); err == nil {
+		t.Fatal("Verify() error = nil, want error for tampered key field")
+	}
+}
+
+func TestVerifyRejectsOutOfRangeContentLength(t *testing.T) {
+	_, creds, sp := signedTestPolicy(t)
+	v := NewVerifier(testLookup(creds))
+
+	if err := v.Verify(formValues(sp), 5000, time.Now()); err == nil {
+		t.Fatal("Verify() error = nil, want error for file size outside content-length-range")
+	}
+}
+
+func TestVerifyRejectsExpiredPolicy(t *testing.T) {
+	_, creds, sp := signedTestPolicy(t)
+	v := NewVerifier(testLookup(creds))
+
+	future := time.Now().Add(2 * time.Hour)
+	if err := v.Verify(formValues(sp), 500, future); err == nil {
+		t.Fatal("Verify() error = nil, want error for expired policy")
+	}
+}
+
+func TestVerifyRejectsBadSignature(t *testing.T) {
+	_, creds, sp := signedTestPolicy(t)
+	v := NewVerifier(testLookup(creds))
+
+	values := formValues(sp)
+	values.Set("x-amz-signature", "0000000000000000000000000000000000000000000000000000000000000000")
+
+	if err := v.Verify(values, 500, time.Now()); err == nil {
+		t.Fatal("Verify() error = nil, want error for bad signature")
+	}
+}
+
+func TestVerifyRejectsDuplicateFieldValues(t *testing.T) {
+	_, creds, sp := signedTestPolicy(t)
+	v := NewVerifier(testLookup(creds))
+
+	values := formValues(sp)
+	values.Add("key", "uploads/evil.txt")
+
+	if err := v.Verify(values, 500, time.Now()); err == nil {
+		t.Fatal("Verify() error = nil, want error for duplicate key field values")
+	}
+}
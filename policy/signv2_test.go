@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignV2ProducesExpectedSignature(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.WithExpiresIn(0)
+
+	creds := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}
+
+	sp, err := SignV2(p, creds)
+	if err != nil {
+		t.Fatalf("SignV2() error = %v", err)
+	}
+
+	if sp.AWSAccessKeyID != creds.AccessKeyID {
+		t.Errorf("AWSAccessKeyID = %q, want %q", sp.AWSAccessKeyID, creds.AccessKeyID)
+	}
+
+	h := hmac.New(sha1.New, []byte(creds.SecretAccessKey))
+	h.Write([]byte(sp.Policy))
+	want := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	if sp.Signature != want {
+		t.Errorf("Signature = %q, want %q", sp.Signature, want)
+	}
+}
+
+func TestSignV2FormFields(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.WithExpiresIn(0)
+
+	creds := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}
+	sp, err := SignV2(p, creds)
+	if err != nil {
+		t.Fatalf("SignV2() error = %v", err)
+	}
+
+	fields := sp.FormFields()
+	for _, key := range []string{"AWSAccessKeyId", "policy", "signature"} {
+		if fields[key] == "" {
+			t.Errorf("FormFields()[%q] is empty, want a value", key)
+		}
+	}
+	if _, ok := fields["x-amz-security-token"]; ok {
+		t.Error("FormFields() included x-amz-security-token with no SessionToken set")
+	}
+}
+
+func TestSignV2InjectsSecurityTokenCondition(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.WithExpiresIn(0)
+
+	creds := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: "TOKEN"}
+	sp, err := SignV2(p, creds)
+	if err != nil {
+		t.Fatalf("SignV2() error = %v", err)
+	}
+
+	if sp.SecurityToken != "TOKEN" {
+		t.Errorf("SecurityToken = %q, want %q", sp.SecurityToken, "TOKEN")
+	}
+
+	fields := sp.FormFields()
+	if fields["x-amz-security-token"] != "TOKEN" {
+		t.Errorf(`FormFields()["x-amz-security-token"] = %q, want "TOKEN"`, fields["x-amz-security-token"])
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(sp.Policy)
+	if err != nil {
+		t.Fatalf("decoding signed policy: %v", err)
+	}
+	if !strings.Contains(string(decoded), `"x-amz-security-token":"TOKEN"`) {
+		t.Errorf("signed policy document does not contain an x-amz-security-token condition: %s", decoded)
+	}
+}
+
+func TestSignInjectsSecurityTokenCondition(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.WithExpiresIn(0)
+
+	creds := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: "TOKEN"}
+	sp, err := Sign(p, creds, "us-east-1", "s3", time.Now())
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	if fields := sp.FormFields(); fields["x-amz-security-token"] != "TOKEN" {
+		t.Errorf(`FormFields()["x-amz-security-token"] = %q, want "TOKEN"`, fields["x-amz-security-token"])
+	}
+}
+
+func TestSignV2RejectsInvalidPolicy(t *testing.T) {
+	var p Policy // no bucket condition
+
+	creds := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}
+	if _, err := SignV2(p, creds); err == nil {
+		t.Fatal("SignV2() error = nil, want error for a policy missing a bucket condition")
+	}
+}
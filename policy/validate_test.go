@@ -0,0 +1,150 @@
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+func findCondition(conditions []condition, key ConditionKey) (condition, bool) {
+	for _, c := range conditions {
+		if c.key == key {
+			return c, true
+		}
+	}
+	return condition{}, false
+}
+
+func TestValidateRequiresBucket(t *testing.T) {
+	var p Policy
+	p.SetKey("uploads/file.txt")
+
+	if err := p.Validate(); err == nil {
+		t.Fatal("Validate() error = nil, want error for missing bucket condition")
+	}
+}
+
+func TestValidateRejectsContentLengthRangeViaWrongSetter(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.SetCondition(ConditionKeyContentLengthRange, "1000", ConditionMatchExact)
+
+	if err := p.Validate(); err == nil {
+		t.Fatal("Validate() error = nil, want error for content-length-range set as an exact match")
+	}
+}
+
+func TestValidateRejectsSuccessActionRedirectAndStatusTogether(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.SetSuccessActionRedirect("https://example.com/done")
+	p.SetSuccessActionStatus(SuccessActionStatusOK)
+
+	if err := p.Validate(); err == nil {
+		t.Fatal("Validate() error = nil, want error for success_action_redirect and success_action_status both set")
+	}
+}
+
+func TestValidateRejectsBadSuccessActionStatus(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.SetCondition(ConditionKeySuccessActionStatus, "418", ConditionMatchExact)
+
+	if err := p.Validate(); err == nil {
+		t.Fatal("Validate() error = nil, want error for success_action_status outside {200,201,204}")
+	}
+}
+
+func TestValidateRejectsDuplicateExactCondition(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.SetKey("uploads/file.txt")
+	p.SetKey("uploads/other.txt")
+
+	if err := p.Validate(); err == nil {
+		t.Fatal("Validate() error = nil, want error for duplicate exact-match condition on the same key")
+	}
+}
+
+func TestValidateRejectsPartialAMZConditionSet(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.SetCondition(ConditionKeyAMZAlgorithm, AWSV4SignatureAlgorithm, ConditionMatchExact)
+
+	if err := p.Validate(); err == nil {
+		t.Fatal("Validate() error = nil, want error when only some of x-amz-algorithm/credential/date are set")
+	}
+}
+
+func TestValidatePassesWithAllAMZConditionsSet(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.SetCondition(ConditionKeyAMZAlgorithm, AWSV4SignatureAlgorithm, ConditionMatchExact)
+	p.SetCondition(ConditionKeyAMZCredential, "AKID/20260101/us-east-1/s3/aws4_request", ConditionMatchExact)
+	p.SetCondition(ConditionKeyAMZDate, "20260101T000000Z", ConditionMatchExact)
+
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v, want nil", err)
+	}
+}
+
+func TestTypedConditionConstructors(t *testing.T) {
+	var p Policy
+	p.SetBucket("my-bucket")
+	p.SetACL(ACLPublicRead)
+	p.SetKeyStartsWith("uploads/")
+	p.SetContentType("image/", true)
+	p.SetContentLengthRange(1, 1000)
+	p.SetSuccessActionStatus(SuccessActionStatusCreated)
+	p.SetCacheControl("no-cache")
+	p.SetContentDisposition("attachment")
+	p.SetContentEncoding("gzip")
+	p.SetExpires(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	p.SetMetadata("uuid", "123", false)
+	p.SetAMZHeader("server-side-encryption", "AES256", false)
+
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v, want nil", err)
+	}
+
+	cases := []struct {
+		key        ConditionKey
+		wantValue  string
+		wantMatch  ConditionMatch
+		wantLower  uint64
+		wantUpper  uint64
+		isRangeKey bool
+	}{
+		{key: ConditionKeyBucket, wantValue: "my-bucket", wantMatch: ConditionMatchExact},
+		{key: ConditionKeyACL, wantValue: "public-read", wantMatch: ConditionMatchExact},
+		{key: ConditionKeyKey, wantValue: "uploads/", wantMatch: ConditionMatchStartsWith},
+		{key: ConditionKeyContentType, wantValue: "image/", wantMatch: ConditionMatchStartsWith},
+		{key: ConditionKeySuccessActionStatus, wantValue: "201", wantMatch: ConditionMatchExact},
+		{key: ConditionKeyCacheControl, wantValue: "no-cache", wantMatch: ConditionMatchExact},
+		{key: ConditionKeyContentDisposition, wantValue: "attachment", wantMatch: ConditionMatchExact},
+		{key: ConditionKeyContentEncoding, wantValue: "gzip", wantMatch: ConditionMatchExact},
+		{key: ConditionKeyExpires, wantValue: "Fri, 02 Jan 2026 03:04:05 GMT", wantMatch: ConditionMatchExact},
+		{key: "x-amz-meta-uuid", wantValue: "123", wantMatch: ConditionMatchExact},
+		{key: "x-amz-server-side-encryption", wantValue: "AES256", wantMatch: ConditionMatchExact},
+		{key: ConditionKeyContentLengthRange, wantLower: 1, wantUpper: 1000, wantMatch: ConditionMatchRange, isRangeKey: true},
+	}
+
+	for _, c := range cases {
+		got, ok := findCondition(p.conditions, c.key)
+		if !ok {
+			t.Errorf("condition for %q not found", c.key)
+			continue
+		}
+		if got.match != c.wantMatch {
+			t.Errorf("%q match = %v, want %v", c.key, got.match, c.wantMatch)
+		}
+		if c.isRangeKey {
+			if got.rangeLower != c.wantLower || got.rangeUpper != c.wantUpper {
+				t.Errorf("%q range = [%d, %d], want [%d, %d]", c.key, got.rangeLower, got.rangeUpper, c.wantLower, c.wantUpper)
+			}
+			continue
+		}
+		if got.value != c.wantValue {
+			t.Errorf("%q value = %q, want %q", c.key, got.value, c.wantValue)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package policy
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// fields that are never required to be covered by a policy condition.
+var ignorableFields = map[string]bool{
+	"x-amz-signature": true,
+	"file":            true,
+	"policy":          true,
+}
+
+// SecretLookupFunc looks up the secret access key for an AWS access key ID.
+// ok is false if accessKey is unknown.
+type SecretLookupFunc func(accessKey string) (secret string, ok bool)
+
+// Verifier checks POST policies and their SigV4 signatures on the receiving
+// end of a browser upload, for services that accept the same policies this
+// package generates.
+type Verifier struct {
+	// Lookup resolves the secret access key used to sign a request from its
+	// access key ID.
+	Lookup SecretLookupFunc
+}
+
+// NewVerifier returns a Verifier that resolves secrets with lookup.
+func NewVerifier(lookup SecretLookupFunc) *Verifier {
+	return &Verifier{Lookup: lookup}
+}
+
+// decodedCondition is a condition as read back out of a submitted policy
+// document, before being checked against the submitted form values.
+type decodedCondition struct {
+	key        ConditionKey
+	value      string
+	rangeLower uint64
+	rangeUpper uint64
+	match      ConditionMatch
+}
+
+// policyDoc mirrors policyJSON for unmarshaling a submitted policy document.
+type policyDoc struct {
+	Expiration string            `json:"expiration"`
+	Conditions []json.RawMessage `json:"conditions"`
+}
+
+// VerifyRequest parses r as a multipart/form-data POST upload and verifies
+// its policy and signature as of now. r.ParseMultipartForm must not have
+// been called with a file field larger than the default memory limit, since
+// VerifyRequest calls it itself.
+func (v *Verifier) VerifyRequest(r *http.Request, now time.Time) error {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return fmt.Errorf("policy: parsing multipart form: %w", err)
+	}
+
+	var fileSize int64
+	if r.MultipartForm != nil && len(r.MultipartForm.File["file"]) > 0 {
+		fileSize = r.MultipartForm.File["file"][0].Size
+	}
+
+	return v.Verify(r.PostForm, fileSize, now)
+}
+
+// Verify checks that values contains a policy that hasn't expired, that
+// every submitted form field is covered by a policy condition (except file,
+// policy, x-amz-signature, and x-ignore-* fields), that each condition is
+// satisfied by its corresponding form value, and that x-amz-signature is a
+// valid SigV4 signature of the policy for a known access key. fileSize is
+// the size of the uploaded file, checked against any content-length-range
+// condition.
+//
+// Form field names are matched case-insensitively, since a gateway turning
+// form fields into HTTP headers would treat them the same way: a field is
+// "covered" and looked up using the same lower-cased name, and two fields
+// that differ only by case count as the same field for the one-value-only
+// check.
+func (v *Verifier) Verify(values url.Values, fileSize int64, now time.Time) error {
+	fieldValue, fieldCount := normalizeFields(values)
+
+	encoded := fieldValue["policy"]
+	if encoded == "" {
+		return fmt.Errorf("policy: missing policy field")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("policy: decoding policy: %w", err)
+	}
+
+	var doc policyDoc
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("policy: parsing policy: %w", err)
+	}
+
+	expiration, err := time.Parse("2006-01-02T15:04:05.000Z", doc.Expiration)
+	if err != nil {
+		return fmt.Errorf("policy: parsing expiration: %w", err)
+	}
+	if !now.Before(expiration) {
+		return fmt.Errorf("policy: expired at %s", doc.Expiration)
+	}
+
+	conditions := make([]decodedCondition, len(doc.Conditions))
+	covered := map[string]bool{}
+	for i, raw := range doc.Conditions {
+		c, err := decodeCondition(raw)
+		if err != nil {
+			return err
+		}
+		conditions[i] = c
+		covered[strings.ToLower(string(c.key))] = true
+	}
+
+	for lower, count := range fieldCount {
+		if ignorableFields[lower] || strings.HasPrefix(lower, "x-ignore-") {
+			continue
+		}
+		if !covered[lower] {
+			return fmt.Errorf("policy: field %q is not covered by a policy condition", lower)
+		}
+		if count != 1 {
+			return fmt.Errorf("policy: field %q must have exactly one value (case-insensitive), got %d", lower, count)
+		}
+	}
+
+	for _, c := range conditions {
+		if err := checkCondition(c, fieldValue, fileSize); err != nil {
+			return err
+		}
+	}
+
+	return v.verifySignature(fieldValue, encoded)
+}
+
+// normalizeFields lower-cases every form field name in values, returning the
+// (arbitrarily chosen) value seen for each lower-cased name alongside the
+// total number of values submitted under any case variant of that name, so
+// case-insensitive coverage and duplicate checks agree with each other.
+func normalizeFields(values url.Values) (value map[string]string, count map[string]int) {
+	value = make(map[string]string, len(values))
+	count = make(map[string]int, len(values))
+	for key, vals := range values {
+		lower := strings.ToLower(key)
+		count[lower] += len(vals)
+		if len(vals) > 0 {
+			value[lower] = vals[0]
+		}
+	}
+	return value, count
+}
+
+// decodeCondition turns a single JSON condition back into a decodedCondition.
+func decodeCondition(raw json.RawMessage) (decodedCondition, error) {
+	var exact map[string]string
+	if err := json.Unmarshal(raw, &exact); err == nil {
+		for k, v := range exact {
+			return decodedCondition{key: ConditionKey(k), value: v, match: ConditionMatchExact}, nil
+		}
+		return decodedCondition{}, fmt.Errorf("policy: empty exact-match condition")
+	}
+
+	var arr [3]interface{}
+	if err := json.Unmarshal(raw, &arr); err != nil {
+		return decodedCondition{}, fmt.Errorf("policy: unrecognized condition %s: %w", raw, err)
+	}
+
+	op, _ := arr[0].(string)
+	if op == "starts-with" {
+		key := strings.TrimPrefix(fmt.Sprint(arr[1]), "$")
+		value, _ := arr[2].(string)
+		match := ConditionMatchStartsWith
+		if value == "" {
+			match = ConditionMatchAny
+		}
+		return decodedCondition{key: ConditionKey(key), value: value, match: match}, nil
+	}
+
+	lower, _ := arr[1].(float64)
+	upper, _ := arr[2].(float64)
+	return decodedCondition{
+		key:        ConditionKey(op),
+		rangeLower: uint64(lower),
+		rangeUpper: uint64(upper),
+		match:      ConditionMatchRange,
+	}, nil
+}
+
+// checkCondition verifies that the submitted form values (or fileSize, for
+// content-length-range) satisfy c. values is keyed by lower-cased field
+// name, as returned by normalizeFields.
+func checkCondition(c decodedCondition, values map[string]string, fileSize int64) error {
+	lower := strings.ToLower(string(c.key))
+	switch c.match {
+	case ConditionMatchExact:
+		if values[lower] != c.value {
+			return fmt.Errorf("policy: field %q does not match required value", c.key)
+		}
+	case ConditionMatchStartsWith:
+		if !strings.HasPrefix(values[lower], c.value) {
+			return fmt.Errorf("policy: field %q does not start with required prefix", c.key)
+		}
+	case ConditionMatchAny:
+		// Any value (including absence) satisfies this condition.
+	case ConditionMatchRange:
+		size := uint64(fileSize)
+		if size < c.rangeLower || size > c.rangeUpper {
+			return fmt.Errorf("policy: file size %d outside allowed range [%d, %d]", fileSize, c.rangeLower, c.rangeUpper)
+		}
+	}
+	return nil
+}
+
+// verifySignature recomputes the SigV4 signature of encodedPolicy and
+// compares it, in constant time, to the x-amz-signature form value. values
+// is keyed by lower-cased field name, as returned by normalizeFields.
+func (v *Verifier) verifySignature(values map[string]string, encodedPolicy string) error {
+	credential := values["x-amz-credential"]
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 {
+		return fmt.Errorf("policy: malformed x-amz-credential")
+	}
+	accessKey, dateStamp, region, service := parts[0], parts[1], parts[2], parts[3]
+
+	secret, ok := v.Lookup(accessKey)
+	if !ok {
+		return fmt.Errorf("policy: unknown access key %q", accessKey)
+	}
+
+	key := signingKeyV4(secret, dateStamp, region, service)
+	expected := hmacSHA256(key, []byte(encodedPolicy))
+
+	got, err := hex.DecodeString(values["x-amz-signature"])
+	if err != nil {
+		return fmt.Errorf("policy: decoding x-amz-signature: %w", err)
+	}
+
+	if !hmac.Equal(expected, got) {
+		return fmt.Errorf("policy: signature mismatch")
+	}
+
+	return nil
+}